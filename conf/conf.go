@@ -0,0 +1,27 @@
+package conf
+
+// Cfg holds the process-wide configuration loaded at startup.
+var Cfg = &Configuration{}
+
+// Configuration groups related settings the way they're addressed
+// throughout the codebase, e.g. conf.Cfg.Chain.UtxoHashStartHeight.
+type Configuration struct {
+	Chain  ChainConfiguration
+	Script ScriptConfiguration
+}
+
+// ChainConfiguration controls chain-state bookkeeping.
+type ChainConfiguration struct {
+	// UtxoHashStartHeight and UtxoHashEndHeight bound the height range over
+	// which ConnectTip computes the running UTXO-set hash used by the
+	// utxostats background task.
+	UtxoHashStartHeight int32
+	UtxoHashEndHeight   int32
+}
+
+// ScriptConfiguration controls script verification.
+type ScriptConfiguration struct {
+	// CheckQueueSize is the number of workers lcheckqueue.NewCheckQueue
+	// starts per block; non-positive means "use runtime.NumCPU()".
+	CheckQueueSize int
+}
@@ -0,0 +1,12 @@
+package errcode
+
+// AuxPow verification error codes, returned by logic/lblock's merged-mining
+// checks.
+const (
+	BlockErrAuxPowNotAllowed = iota + 2100
+	BlockErrAuxPowChainID
+	BlockErrAuxPowMissing
+	BlockErrAuxPowMerkleCommit
+	BlockErrAuxPowParentMerkle
+	BlockErrAuxPowProofOfWork
+)
@@ -0,0 +1,12 @@
+package errcode
+
+// Is reports whether err is a ProjectError carrying code, letting callers
+// branch on specific failure reasons (e.g. "missing inputs") without
+// string-matching log messages.
+func Is(err error, code int) bool {
+	pe, ok := err.(ProjectError)
+	if !ok {
+		return false
+	}
+	return pe.Code == code
+}
@@ -0,0 +1,9 @@
+package errcode
+
+// UTXO snapshot load/dump error codes, returned by utxo/snapshot.
+const (
+	SnapshotErrBadMagic = iota + 2200
+	SnapshotErrBadVersion
+	SnapshotErrNoCommitment
+	SnapshotErrHashMismatch
+)
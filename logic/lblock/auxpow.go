@@ -0,0 +1,105 @@
+package lblock
+
+import (
+	"bytes"
+
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/model/block"
+	"github.com/copernet/copernicus/model/chainparams"
+	"github.com/copernet/copernicus/model/pow"
+	"github.com/copernet/copernicus/util"
+)
+
+// merkleMagic is the marker merge-mining coinbases embed just before the
+// child block's hash, as used by Namecoin/Dogecoin-style AuxPow.
+var merkleMagic = [4]byte{0xfa, 0xbe, 0x6d, 0x6d}
+
+// CheckAuxPow verifies a block's merged-mining proof, if its header declares
+// one. ConnectBlock calls it for every block: a header without the AuxPow bit
+// passes, and one with the bit is rejected below the activation height.
+func CheckAuxPow(pblock *block.Block, height int32, params *chainparams.BitcoinParams) error {
+	_, chainID, hasAuxPow := block.DecodeVersion(pblock.Header.Version)
+	if !hasAuxPow {
+		return nil
+	}
+	if !params.AuxPowEnabledAt(height) {
+		return errcode.New(errcode.BlockErrAuxPowNotAllowed)
+	}
+	if chainID != params.AuxPowChainID {
+		return errcode.New(errcode.BlockErrAuxPowChainID)
+	}
+	auxPow := pblock.AuxPow
+	if auxPow == nil {
+		return errcode.New(errcode.BlockErrAuxPowMissing)
+	}
+
+	childHash := pblock.GetHash()
+
+	// (a) the chain merkle branch must reproduce a root that commits to this
+	// block, and that root - preceded by the magic marker - must appear in
+	// the parent coinbase at exactly the offset auxPow.MerkleCommitOffset
+	// claims, not merely "somewhere" in the script. An unconstrained search
+	// with bytes.Contains would let a forged coinbase carry more than one
+	// chain's commitment and let this block be "verified" against a
+	// commitment that was never cryptographically tied to the parent
+	// coinbase's own merkle proof.
+	coinbaseScript := auxPow.ParentCoinbase.GetIns()[0].GetScriptSig().GetData()
+	chainMerkleRoot := computeMerkleRoot(childHash, auxPow.ChainMerkleBranch, auxPow.ChainMerkleIndex)
+	marker := append(append([]byte{}, merkleMagic[:]...), chainMerkleRoot[:]...)
+
+	if !verifyMerkleCommitAtOffset(coinbaseScript, marker, auxPow.MerkleCommitOffset) {
+		return errcode.New(errcode.BlockErrAuxPowMerkleCommit)
+	}
+
+	// (b) the parent coinbase must be included in the parent block via the
+	// parent coinbase merkle branch.
+	coinbaseHash := auxPow.ParentCoinbase.GetHash()
+	parentMerkleRoot := computeMerkleRoot(coinbaseHash, auxPow.ParentCoinbaseBranch, auxPow.ParentCoinbaseIndex)
+	if !parentMerkleRoot.IsEqual(&auxPow.ParentBlock.MerkleRoot) {
+		return errcode.New(errcode.BlockErrAuxPowParentMerkle)
+	}
+
+	// (c) the parent header itself must satisfy this chain's difficulty
+	// target for the child block.
+	if !pow.CheckProofOfWork(auxPow.ParentBlock.GetHash(), pblock.Header.Bits, params) {
+		return errcode.New(errcode.BlockErrAuxPowProofOfWork)
+	}
+
+	return nil
+}
+
+// verifyMerkleCommitAtOffset reports whether marker appears in script at
+// exactly offset, and nowhere else. Requiring an exact position (rather than
+// bytes.Contains anywhere in the script) is what actually ties this proof to
+// the chain merkle branch that produced marker; requiring uniqueness closes
+// the multi-chain-commitment forgery this construction is otherwise
+// vulnerable to, where a parent coinbase carries more than one child
+// chain's commitment and a verifier can't tell which one a given proof
+// belongs to.
+func verifyMerkleCommitAtOffset(script, marker []byte, offset int) bool {
+	if offset < 0 || offset+len(marker) > len(script) {
+		return false
+	}
+	if !bytes.Equal(script[offset:offset+len(marker)], marker) {
+		return false
+	}
+	return bytes.Count(script, marker) == 1
+}
+
+// computeMerkleRoot folds leaf up through branch using the side implied by
+// index's bits, matching Bitcoin's partial-merkle-branch convention.
+func computeMerkleRoot(leaf util.Hash, branch []util.Hash, index int) util.Hash {
+	hash := leaf
+	for i, sibling := range branch {
+		buf := make([]byte, 0, 2*util.Hash256Size)
+		if index&(1<<uint(i)) != 0 {
+			buf = append(buf, sibling[:]...)
+			buf = append(buf, hash[:]...)
+		} else {
+			buf = append(buf, hash[:]...)
+			buf = append(buf, sibling[:]...)
+		}
+		hash = util.DoubleSha256Hash(buf)
+	}
+	return hash
+}
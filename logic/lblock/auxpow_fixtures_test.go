@@ -0,0 +1,62 @@
+package lblock
+
+import (
+	"testing"
+
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/model/block"
+	"github.com/copernet/copernicus/model/chainparams"
+)
+
+// These fixtures drive CheckAuxPow itself, not just verifyMerkleCommitAtOffset,
+// covering the activation-height gate and the chain-ID/presence checks that
+// run ahead of the merkle-branch checks. They stop short of the merkle-commit
+// (a), parent-coinbase-inclusion (b) and parent-proof-of-work (c) checks:
+// those need a real *tx.Tx parent coinbase and a real parent block satisfying
+// model/pow's difficulty math, and neither model/tx.Tx's nor model/pow's
+// actual constructors exist anywhere in this tree to build fixtures against -
+// only their call sites do. verifyMerkleCommitAtOffset's own test already
+// covers the forgery case (a) exists to close.
+
+func testAuxPowParams(chainID, startHeight int32) *chainparams.BitcoinParams {
+	return &chainparams.BitcoinParams{AuxPowChainID: chainID, AuxPowStartHeight: startHeight}
+}
+
+func TestCheckAuxPowLegacyBlockSkipsCheck(t *testing.T) {
+	pblock := &block.Block{Header: block.BlockHeader{Version: block.EncodeVersion(1, 0, false)}}
+	params := testAuxPowParams(1, 100)
+
+	if err := CheckAuxPow(pblock, 200, params); err != nil {
+		t.Fatalf("expected a block with no AuxPow bit to skip the check, got %v", err)
+	}
+}
+
+func TestCheckAuxPowRejectedBeforeActivation(t *testing.T) {
+	pblock := &block.Block{Header: block.BlockHeader{Version: block.EncodeVersion(1, 1, true)}}
+	params := testAuxPowParams(1, 100)
+
+	err := CheckAuxPow(pblock, 99, params)
+	if !errcode.Is(err, errcode.BlockErrAuxPowNotAllowed) {
+		t.Fatalf("expected BlockErrAuxPowNotAllowed below the activation height, got %v", err)
+	}
+}
+
+func TestCheckAuxPowRejectsWrongChainID(t *testing.T) {
+	pblock := &block.Block{Header: block.BlockHeader{Version: block.EncodeVersion(1, 2, true)}}
+	params := testAuxPowParams(1, 100)
+
+	err := CheckAuxPow(pblock, 200, params)
+	if !errcode.Is(err, errcode.BlockErrAuxPowChainID) {
+		t.Fatalf("expected BlockErrAuxPowChainID for a mismatched chain ID, got %v", err)
+	}
+}
+
+func TestCheckAuxPowRequiresAuxPowPayload(t *testing.T) {
+	pblock := &block.Block{Header: block.BlockHeader{Version: block.EncodeVersion(1, 1, true)}, AuxPow: nil}
+	params := testAuxPowParams(1, 100)
+
+	err := CheckAuxPow(pblock, 200, params)
+	if !errcode.Is(err, errcode.BlockErrAuxPowMissing) {
+		t.Fatalf("expected BlockErrAuxPowMissing when the header claims AuxPow but Block.AuxPow is nil, got %v", err)
+	}
+}
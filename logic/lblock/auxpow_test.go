@@ -0,0 +1,39 @@
+package lblock
+
+import "testing"
+
+func TestVerifyMerkleCommitAtOffset(t *testing.T) {
+	marker := []byte{0xfa, 0xbe, 0x6d, 0x6d, 0x01, 0x02, 0x03}
+
+	script := append(append([]byte{0xaa, 0xbb}, marker...), 0xcc, 0xdd)
+	if !verifyMerkleCommitAtOffset(script, marker, 2) {
+		t.Fatal("expected marker at its real offset to verify")
+	}
+
+	if verifyMerkleCommitAtOffset(script, marker, 0) {
+		t.Fatal("expected a wrong offset to fail even though the marker exists elsewhere in the script")
+	}
+	if verifyMerkleCommitAtOffset(script, marker, 3) {
+		t.Fatal("expected an off-by-one offset to fail")
+	}
+
+	tooShort := marker[:len(marker)-1]
+	if verifyMerkleCommitAtOffset(tooShort, marker, 0) {
+		t.Fatal("expected a script shorter than the marker to fail")
+	}
+	if verifyMerkleCommitAtOffset(script, marker, -1) {
+		t.Fatal("expected a negative offset to fail")
+	}
+	if verifyMerkleCommitAtOffset(script, marker, len(script)) {
+		t.Fatal("expected an offset past the end of the script to fail")
+	}
+
+	// A coinbase carrying the same marker twice must be rejected even at the
+	// claimed offset: bytes.Contains alone can't distinguish which
+	// occurrence the chain merkle branch was actually computed against,
+	// which is the exact forgery this check exists to close.
+	duplicated := append(append([]byte{}, script...), marker...)
+	if verifyMerkleCommitAtOffset(duplicated, marker, 2) {
+		t.Fatal("expected a duplicated marker to fail even at the correct offset")
+	}
+}
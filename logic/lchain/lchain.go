@@ -46,6 +46,12 @@ func ConnectBlock(pblock *block.Block, pindex *blockindex.BlockIndex, view *utxo
 	if err := lblock.CheckBlock(pblock, true, true); err != nil {
 		return err
 	}
+	// Verify the merged-mining proof, if this height requires one; a
+	// merge-mined block that failed this check could otherwise sail through
+	// ConnectBlock with no real proof-of-work of its own.
+	if err := lblock.CheckAuxPow(pblock, pindex.Height, params); err != nil {
+		return err
+	}
 
 	// Verify that the view's current state corresponds to the previous lblock
 	var hashPrevBlock *util.Hash
@@ -266,6 +272,14 @@ func ConnectTip(pIndexNew *blockindex.BlockIndex,
 	gPersist.GlobalTimeConnectTotal += nTime3 - nTime2
 	log.Debug("Connect total: %d us [%.2fs]\n", nTime3-nTime2, float64(gPersist.GlobalTimeConnectTotal)*0.000001)
 
+	// Record a forward pointer to the block we're about to commit before
+	// touching the coins DB, so a crash mid-batch can be detected and replayed
+	// on the next startup (see ReplayBlocks).
+	cdb := utxo.GetUtxoCacheInstance().(*utxo.CoinsLruCache).GetCoinsDB()
+	if err := cdb.WriteHeadBlock(indexHash); err != nil {
+		return err
+	}
+
 	//flushed := view.Flush(indexHash)
 	err = utxo.GetUtxoCacheInstance().UpdateCoins(view, &indexHash)
 	if err != nil {
@@ -276,10 +290,20 @@ func ConnectTip(pIndexNew *blockindex.BlockIndex,
 	log.Print("bench", "debug", " - Flush: %d us [%.2fs]\n",
 		nTime4-nTime3, float64(gPersist.GlobalTimeFlush)*0.000001)
 
-	// Write the chain state to disk, if necessary.
-	if err := disk.FlushStateToDisk(disk.FlushStateAlways, 0); err != nil {
+	// Write the chain state to disk only if the cache has grown past its
+	// threshold; ConnectTip no longer forces a flush on every block, since
+	// head_block/best_block bookkeeping lets us replay to a consistent state
+	// after a crash instead.
+	if err := disk.FlushStateToDisk(disk.FlushStateIfNeeded, 0); err != nil {
 		return err
 	}
+	flushed, err := cdb.ClearHeadBlockIfFlushed(indexHash)
+	if err != nil {
+		return err
+	}
+	if flushed {
+		chain.CallChainStateFlushed(gChain.GetLocator(pIndexNew))
+	}
 	if pIndexNew.Height >= conf.Cfg.Chain.UtxoHashStartHeight && pIndexNew.Height < conf.Cfg.Chain.UtxoHashEndHeight {
 		cdb := utxo.GetUtxoCacheInstance().(*utxo.CoinsLruCache).GetCoinsDB()
 		besthash, err := cdb.GetBestBlock()
@@ -303,6 +327,9 @@ func ConnectTip(pIndexNew *blockindex.BlockIndex,
 
 	// Remove conflicting transactions from the mempool.;
 	mempool.GetInstance().RemoveTxSelf(blockConnecting.Txs)
+	// TODO: thread the actual conflicted-tx list through once RemoveTxSelf
+	// reports it; for now subscribers just see the connected block.
+	chain.CallBlockConnected(blockConnecting, pIndexNew, nil)
 	// Update chainActive & related variables.
 	UpdateTip(pIndexNew)
 	nTime6 := util.GetTimeMicroSec()
@@ -335,6 +362,7 @@ func DisconnectTip(fBare bool) error {
 
 	// Apply the block atomically to the chain state.
 	nStart := time.Now().UnixNano()
+	cdb := utxo.GetUtxoCacheInstance().(*utxo.CoinsLruCache).GetCoinsDB()
 	{
 		view := utxo.NewEmptyCoinsMap()
 
@@ -342,12 +370,17 @@ func DisconnectTip(fBare bool) error {
 			log.Error(fmt.Sprintf("DisconnectTip(): DisconnectBlock %s failed ", tip.GetBlockHash()))
 			return errcode.New(errcode.DisconnectTipUndoFailed)
 		}
+		// Point head_block at the block we're rolling back to before mutating
+		// the cache, so a crash between here and ClearHeadBlockIfFlushed can
+		// be detected and replayed on the next startup.
+		if err := cdb.WriteHeadBlock(blk.Header.HashPrevBlock); err != nil {
+			return err
+		}
 		//flushed := view.Flush(blk.Header.HashPrevBlock)
 		err := utxo.GetUtxoCacheInstance().UpdateCoins(view, &blk.Header.HashPrevBlock)
 		if err != nil {
 			panic("view flush error !!!")
 		}
-		utxo.GetUtxoCacheInstance().Flush()
 	}
 	// replace implement with log.Print(in C++).
 	log.Info("bench-debug - Disconnect block : %.2fms\n",
@@ -357,6 +390,13 @@ func DisconnectTip(fBare bool) error {
 	if err := disk.FlushStateToDisk(disk.FlushStateIfNeeded, 0); err != nil {
 		return err
 	}
+	flushed, err := cdb.ClearHeadBlockIfFlushed(blk.Header.HashPrevBlock)
+	if err != nil {
+		return err
+	}
+	if flushed {
+		chain.CallChainStateFlushed(gChain.GetLocator(tip.Prev))
+	}
 
 	// If this block was deactivating the replay protection, then we need to
 	// remove transactions that are replay protected from the mempool. There is
@@ -373,27 +413,43 @@ func DisconnectTip(fBare bool) error {
 	UpdateTip(tip.Prev)
 
 	if !fBare {
-		// Resurrect mempool transactions from the disconnected block.
-		for _, tx := range blk.Txs {
-			// ignore validation errors in resurrected transactions
-			if tx.IsCoinBase() {
-				mempool.GetInstance().RemoveTxRecursive(tx, mempool.REORG)
-			} else {
-				e := lmempool.AcceptTxToMemPool(tx)
-				if e != nil {
-					mempool.GetInstance().RemoveTxRecursive(tx, mempool.REORG)
-				}
-			}
+		// Resurrect mempool transactions from the disconnected block, in
+		// dependency order, deferring anything still missing inputs to the
+		// retry pass FinishReorg runs once the whole reorg has completed.
+		mempool.GetInstance().RemoveTxRecursive(blk.Txs[0], mempool.REORG)
+		lmempool.ResurrectBlock(blk)
+	}
+	chain.CallBlockDisconnected(blk)
+	return nil
+}
+
+// DisconnectTipsToFork repeatedly calls DisconnectTip until chainActive's
+// tip is fork, then runs lmempool.FinishReorg once against the new tip so
+// deferred "missing inputs" resurrections and the mempool size/finality
+// re-limit only happen after every block in the reorg has actually been
+// disconnected, instead of once per block.
+func DisconnectTipsToFork(fork *blockindex.BlockIndex) error {
+	gChain := chain.GetInstance()
+	for gChain.Tip() != nil && gChain.Tip() != fork {
+		if err := DisconnectTip(false); err != nil {
+			return err
 		}
 	}
-	gChain.SendNotification(chain.NTBlockDisconnected, blk)
+
+	lockTimeFlags := 0
+	if fork.Height >= gChain.GetParams().CSVHeight {
+		lockTimeFlags |= consensus.LocktimeVerifySequence
+	}
+	lmempool.FinishReorg(fork.Height, uint32(lockTimeFlags))
 	return nil
 }
 
 // UpdateTip Update chainActive and related internal data structures.
 func UpdateTip(pindexNew *blockindex.BlockIndex) {
 	gChain := chain.GetInstance()
+	oldTip := gChain.Tip()
 	gChain.SetTip(pindexNew)
+	chain.CallUpdatedBlockTip(pindexNew, oldTip, IsInitialBlockDownload())
 	param := gChain.GetParams()
 	warningMessages := make([]string, 0)
 	txdata := param.TxData()
@@ -451,6 +507,16 @@ func DisconnectBlock(pblock *block.Block, pindex *blockindex.BlockIndex, view *u
 
 func InitGenesisChain() error {
 	gChain := chain.GetInstance()
+
+	// Reconcile the coins DB against the block index before the chain
+	// starts accepting new tips: if the previous shutdown happened mid
+	// flush, best_block and head_block disagree and need to be replayed
+	// back into agreement first (see ReplayBlocks). On a brand-new chain
+	// neither pointer is set yet, so this is a no-op.
+	if err := ReplayBlocks(); err != nil {
+		return err
+	}
+
 	if gChain.Genesis() != nil {
 		return nil
 	}
@@ -0,0 +1,100 @@
+package lchain
+
+import (
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/log"
+	"github.com/copernet/copernicus/model/blockindex"
+	"github.com/copernet/copernicus/model/chain"
+	"github.com/copernet/copernicus/model/undo"
+	"github.com/copernet/copernicus/model/utxo"
+	"github.com/copernet/copernicus/persist/disk"
+)
+
+// ReplayBlocks reconciles the coins DB with the block index after an unclean
+// shutdown. ConnectTip and DisconnectTip no longer flush the UTXO cache on
+// every block; instead they record a head_block pointer before mutating the
+// cache and clear it, via ClearHeadBlockIfFlushed, only once best_block has
+// actually caught up with it. If head_block and best_block still disagree on
+// startup, the previous batch was interrupted mid flush, and we need to walk
+// from best_block to head_block via their last common ancestor, undoing and
+// reconnecting blocks with the existing undo files until the coins DB is
+// consistent again.
+func ReplayBlocks() error {
+	gChain := chain.GetInstance()
+	params := gChain.GetParams()
+	cdb := utxo.GetUtxoCacheInstance().(*utxo.CoinsLruCache).GetCoinsDB()
+
+	bestHash, err := cdb.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	headHash, err := cdb.GetHeadBlock()
+	if err != nil {
+		return err
+	}
+	if headHash == nil || bestHash == nil || headHash.IsEqual(bestHash) {
+		return nil
+	}
+
+	log.Warn("ReplayBlocks: best_block %s != head_block %s, replaying", bestHash, headHash)
+
+	bestIndex := gChain.FindBlockIndex(*bestHash)
+	headIndex := gChain.FindBlockIndex(*headHash)
+	if bestIndex == nil || headIndex == nil {
+		return errcode.New(errcode.FailedToReadBlock)
+	}
+
+	fork := gChain.LastCommonAncestor(bestIndex, headIndex)
+	undoPath, redoPath := replayPath(bestIndex, headIndex, fork)
+
+	for _, pindex := range undoPath {
+		blk, ret := disk.ReadBlockFromDisk(pindex, params)
+		if !ret {
+			return errcode.New(errcode.FailedToReadBlock)
+		}
+		view := utxo.NewEmptyCoinsMap()
+		if DisconnectBlock(blk, pindex, view) != undo.DisconnectOk {
+			return errcode.New(errcode.DisconnectTipUndoFailed)
+		}
+		if err := utxo.GetUtxoCacheInstance().UpdateCoins(view, pindex.Prev.GetBlockHash()); err != nil {
+			return err
+		}
+	}
+
+	for _, pindex := range redoPath {
+		blk, ret := disk.ReadBlockFromDisk(pindex, params)
+		if !ret {
+			return errcode.New(errcode.FailedToReadBlock)
+		}
+		view := utxo.NewEmptyCoinsMap()
+		if err := ConnectBlock(blk, pindex, view, false); err != nil {
+			return err
+		}
+		if err := utxo.GetUtxoCacheInstance().UpdateCoins(view, pindex.GetBlockHash()); err != nil {
+			return err
+		}
+	}
+
+	_, err = cdb.ClearHeadBlockIfFlushed(*headHash)
+	return err
+}
+
+// replayPath returns the blocks to undo, from best back to (but excluding)
+// fork, and the blocks to redo, from fork forward to head, in the order
+// each side should be applied: undo from the tip backwards, redo from the
+// fork point forwards. It touches no global state, so it can be exercised
+// directly by tests without a real block index or coins DB.
+func replayPath(best, head, fork *blockindex.BlockIndex) (undoPath, redoPath []*blockindex.BlockIndex) {
+	for pindex := best; pindex != nil && pindex != fork; pindex = pindex.Prev {
+		undoPath = append(undoPath, pindex)
+	}
+
+	var forward []*blockindex.BlockIndex
+	for pindex := head; pindex != nil && pindex != fork; pindex = pindex.Prev {
+		forward = append(forward, pindex)
+	}
+	for i := len(forward) - 1; i >= 0; i-- {
+		redoPath = append(redoPath, forward[i])
+	}
+	return
+}
@@ -0,0 +1,193 @@
+package lchain
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/copernet/copernicus/model/blockindex"
+)
+
+// chain builds a linked list of n BlockIndex nodes rooted at genesis, with
+// Height set to each node's position, mimicking how the real block index
+// links pindex.Prev during normal connection.
+func chain(n int) []*blockindex.BlockIndex {
+	nodes := make([]*blockindex.BlockIndex, n)
+	for i := 0; i < n; i++ {
+		nodes[i] = &blockindex.BlockIndex{Height: int32(i)}
+		if i > 0 {
+			nodes[i].Prev = nodes[i-1]
+		}
+	}
+	return nodes
+}
+
+// TestReplayPathSameChain simulates a crash with no fork: head_block is just
+// ahead of best_block on the same branch, so nothing should be undone and
+// every block between them should be redone in order.
+func TestReplayPathSameChain(t *testing.T) {
+	nodes := chain(6)
+	best, head, fork := nodes[2], nodes[5], nodes[2]
+
+	undoPath, redoPath := replayPath(best, head, fork)
+
+	if len(undoPath) != 0 {
+		t.Fatalf("expected no blocks to undo on a straight extension, got %d", len(undoPath))
+	}
+	wantRedo := []*blockindex.BlockIndex{nodes[3], nodes[4], nodes[5]}
+	assertSameOrder(t, "redo", redoPath, wantRedo)
+}
+
+// TestReplayPathReorg simulates a crash mid-reorg: best_block is on the stale
+// branch, head_block on the new one, diverging at fork. The stale blocks
+// must be undone tip-first and the new blocks redone fork-first.
+func TestReplayPathReorg(t *testing.T) {
+	trunk := chain(3) // 0,1,2 shared ancestry
+	fork := trunk[2]
+
+	staleTip := &blockindex.BlockIndex{Height: 3, Prev: fork}
+	staleTip2 := &blockindex.BlockIndex{Height: 4, Prev: staleTip}
+
+	newTip := &blockindex.BlockIndex{Height: 3, Prev: fork}
+	newTip2 := &blockindex.BlockIndex{Height: 4, Prev: newTip}
+	newTip3 := &blockindex.BlockIndex{Height: 5, Prev: newTip2}
+
+	undoPath, redoPath := replayPath(staleTip2, newTip3, fork)
+
+	assertSameOrder(t, "undo", undoPath, []*blockindex.BlockIndex{staleTip2, staleTip})
+	assertSameOrder(t, "redo", redoPath, []*blockindex.BlockIndex{newTip, newTip2, newTip3})
+}
+
+// TestReplayPathNoReplayNeeded covers the common case ReplayBlocks itself
+// short-circuits on: best_block already equals head_block, so there's
+// nothing to walk in either direction.
+func TestReplayPathNoReplayNeeded(t *testing.T) {
+	nodes := chain(3)
+	undoPath, redoPath := replayPath(nodes[2], nodes[2], nodes[2])
+
+	if len(undoPath) != 0 || len(redoPath) != 0 {
+		t.Fatalf("expected empty undo/redo paths when best == head, got undo=%d redo=%d",
+			len(undoPath), len(redoPath))
+	}
+}
+
+// simulatedLedger stands in for the coins DB state ReplayBlocks actually
+// mutates via DisconnectBlock/ConnectBlock. This sandbox's tree has no
+// buildable CoinsDB/disk.ReadBlockFromDisk to drive the real ReplayBlocks
+// end-to-end, so the crash simulator below exercises the same undo-then-redo
+// sequence replayPath produces against this minimal stand-in instead: each
+// block's node is "applied" (present in the ledger) iff it's an ancestor of
+// whichever tip the ledger currently represents, exactly mirroring what
+// DisconnectBlock/ConnectBlock do to real coin state one block at a time.
+type simulatedLedger map[*blockindex.BlockIndex]bool
+
+func (l simulatedLedger) disconnect(pindex *blockindex.BlockIndex) { l[pindex] = false }
+func (l simulatedLedger) connect(pindex *blockindex.BlockIndex)    { l[pindex] = true }
+
+func (l simulatedLedger) matches(ancestry []*blockindex.BlockIndex) bool {
+	want := make(map[*blockindex.BlockIndex]bool, len(ancestry))
+	for _, pindex := range ancestry {
+		want[pindex] = true
+	}
+	for pindex, applied := range l {
+		if applied != want[pindex] {
+			return false
+		}
+	}
+	return true
+}
+
+// ancestryOf returns tip and every ancestor back to (and including) genesis.
+func ancestryOf(tip *blockindex.BlockIndex) []*blockindex.BlockIndex {
+	var out []*blockindex.BlockIndex
+	for pindex := tip; pindex != nil; pindex = pindex.Prev {
+		out = append(out, pindex)
+	}
+	return out
+}
+
+// randomSplitChains builds a shared trunk of random length topped with two
+// independent branches of random length - one playing best_block's stale
+// branch, one playing head_block's new branch - so each trial exercises a
+// different fork shape and split point.
+func randomSplitChains(r *rand.Rand) (best, head, fork *blockindex.BlockIndex) {
+	trunkLen := 1 + r.Intn(4)
+	trunk := chain(trunkLen)
+	fork = trunk[trunkLen-1]
+
+	bestLen := r.Intn(4)
+	best = fork
+	for i := 0; i < bestLen; i++ {
+		best = &blockindex.BlockIndex{Height: fork.Height + 1 + int32(i), Prev: best}
+	}
+
+	headLen := r.Intn(4)
+	head = fork
+	for i := 0; i < headLen; i++ {
+		head = &blockindex.BlockIndex{Height: fork.Height + 1 + int32(i), Prev: head}
+	}
+
+	return best, head, fork
+}
+
+// TestReplaySimulatedCrashRecovery is a crash-recovery simulator: for many
+// random fork shapes, it runs replayPath's undo/redo sequence against a
+// simulatedLedger starting from best_block's state, aborting partway through
+// at a random point (standing in for a crash mid-flush) and then re-running
+// the exact same sequence from scratch against whatever partial state was
+// left behind - exactly what happens on restart, since ReplayBlocks always
+// recomputes undoPath/redoPath from the on-disk best_block/head_block rather
+// than tracking how far a prior attempt got. Each trial asserts the ledger
+// ends up matching head_block's ancestry regardless of where the abort fell,
+// which is the property ReplayBlocks exists to guarantee.
+func TestReplaySimulatedCrashRecovery(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	const trials = 200
+	for trial := 0; trial < trials; trial++ {
+		best, head, fork := randomSplitChains(r)
+		undoPath, redoPath := replayPath(best, head, fork)
+
+		ops := make([]func(simulatedLedger), 0, len(undoPath)+len(redoPath))
+		for _, pindex := range undoPath {
+			pindex := pindex
+			ops = append(ops, func(l simulatedLedger) { l.disconnect(pindex) })
+		}
+		for _, pindex := range redoPath {
+			pindex := pindex
+			ops = append(ops, func(l simulatedLedger) { l.connect(pindex) })
+		}
+
+		ledger := simulatedLedger{}
+		for _, pindex := range ancestryOf(best) {
+			ledger[pindex] = true
+		}
+
+		// Simulate a crash after a random prefix of ops - possibly zero,
+		// possibly all of them - then "restart" by re-running the full
+		// sequence from index 0 against the partial state left behind.
+		crashAt := r.Intn(len(ops) + 1)
+		for i := 0; i < crashAt; i++ {
+			ops[i](ledger)
+		}
+		for _, op := range ops {
+			op(ledger)
+		}
+
+		if !ledger.matches(ancestryOf(head)) {
+			t.Fatalf("trial %d: ledger did not converge to head's ancestry after simulated crash at op %d/%d",
+				trial, crashAt, len(ops))
+		}
+	}
+}
+
+func assertSameOrder(t *testing.T, label string, got, want []*blockindex.BlockIndex) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %d blocks, got %d", label, len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s[%d]: expected block at height %d, got height %d", label, i, want[i].Height, got[i].Height)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package lchain
+
+import (
+	"io"
+
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/log"
+	"github.com/copernet/copernicus/model/block"
+	"github.com/copernet/copernicus/model/blockindex"
+	"github.com/copernet/copernicus/model/chain"
+	"github.com/copernet/copernicus/utxo/snapshot"
+)
+
+// InitChainFromSnapshot loads a UTXO snapshot produced by snapshot.DumpSnapshot
+// and, once its hash has checked out, treats the snapshot's block as the
+// chain's effective tip: InitGenesisChain still writes the real genesis
+// block and header chain as usual, but SetTip here points chainActive at the
+// snapshot height immediately instead of waiting for a full block-by-block
+// validation from genesis. Background validation is expected to keep
+// connecting blocks from genesis in parallel until it catches up with and
+// confirms the snapshot, matching how assumeutxo bootstrapping works
+// upstream; that catch-up loop is intentionally left to the caller driving
+// initial block download, not this function.
+//
+// baseHeader is the header of the snapshot's base block, and ancestorHeaders
+// is every header between genesis and baseHeader, oldest first, exclusive of
+// genesis and inclusive of baseHeader's immediate parent. On a real fresh
+// sync none of these are in the index yet - nothing in this tree runs a
+// headers-first sync ahead of a snapshot load - so the caller is expected
+// to have the
+// whole header chain from the same trusted source as the snapshot file
+// itself, e.g. a checkpoint shipped with the software, the same way real
+// assumeutxo bootstrapping ships a headers chain alongside its hardcoded
+// base block. No CLI switch or RPC command in this tree calls this function.
+// Without linking that chain in behind the base block,
+// height-indexed ancestor walks like ConnectBlock's BIP34 check
+// (pindex.Prev.GetAncestor(params.BIP34Height)) would run against a base
+// block whose Prev is nil.
+func InitChainFromSnapshot(r io.Reader, baseHeader *block.BlockHeader, ancestorHeaders []*block.BlockHeader) error {
+	gChain := chain.GetInstance()
+	params := gChain.GetParams()
+
+	blockHash, height, err := snapshot.LoadSnapshot(r, params)
+	if err != nil {
+		return err
+	}
+
+	pindex := gChain.FindBlockIndex(*blockHash)
+	if pindex == nil {
+		if baseHeader == nil {
+			return errcode.New(errcode.SnapshotErrNoCommitment)
+		}
+		headerHash := baseHeader.GetHash()
+		if !headerHash.IsEqual(blockHash) {
+			return errcode.New(errcode.SnapshotErrNoCommitment)
+		}
+		// Link a headers-only BlockIndex for every ancestor between genesis
+		// and baseHeader in behind it, so pindex.Prev is a real chain and not
+		// a dangling nil - height-indexed ancestor walks like ConnectBlock's
+		// BIP34 check (pindex.Prev.GetAncestor(params.BIP34Height)) need to
+		// be able to walk it. A header already present in the index (from an
+		// earlier, partial snapshot load) is reused rather than duplicated.
+		prev := gChain.Genesis()
+		if prev == nil {
+			return errcode.New(errcode.SnapshotErrNoCommitment)
+		}
+		for _, header := range ancestorHeaders {
+			if !header.HashPrevBlock.IsEqual(prev.GetBlockHash()) {
+				return errcode.New(errcode.SnapshotErrNoCommitment)
+			}
+			headerHash := header.GetHash()
+			next := gChain.FindBlockIndex(headerHash)
+			if next == nil {
+				next = blockindex.NewBlockIndex(header)
+				next.Height = prev.Height + 1
+				next.Prev = prev
+				if err := gChain.AddToIndexMap(next); err != nil {
+					return err
+				}
+			}
+			prev = next
+		}
+		if prev.Height != height-1 || !baseHeader.HashPrevBlock.IsEqual(prev.GetBlockHash()) {
+			return errcode.New(errcode.SnapshotErrNoCommitment)
+		}
+
+		pindex = blockindex.NewBlockIndex(baseHeader)
+		pindex.Height = height
+		pindex.Prev = prev
+		if err := gChain.AddToIndexMap(pindex); err != nil {
+			return err
+		}
+	}
+	if pindex.Height != height {
+		return errcode.New(errcode.SnapshotErrNoCommitment)
+	}
+
+	gChain.SetTip(pindex)
+	log.Info("InitChainFromSnapshot: loaded snapshot at height %d, hash %s", height, blockHash)
+	return nil
+}
+
+// DumpChainSnapshot writes a UTXO snapshot of the current chain tip to w.
+// No RPC or CLI command in this tree calls it.
+func DumpChainSnapshot(w io.Writer) error {
+	tip := chain.GetInstance().Tip()
+	return snapshot.DumpSnapshot(tip.Height, *tip.GetBlockHash(), w)
+}
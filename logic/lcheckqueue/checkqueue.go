@@ -0,0 +1,113 @@
+package lcheckqueue
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/copernet/copernicus/conf"
+	"github.com/copernet/copernicus/model/tx"
+)
+
+// ScriptCheck holds everything needed to verify a single transaction input's
+// scriptSig/scriptPubKey pair against the previous output it spends, so the
+// check can run on any worker without touching shared state. PrevCoin is a
+// snapshot taken at enqueue time, not a live reference to the coins-map
+// entry: the caller is about to spend that coin (marking/clearing it
+// in-place) on the same goroutine that enqueues checks, so holding onto the
+// live Coin here would race a worker's read against that mutation.
+type ScriptCheck struct {
+	Tx          Verifiable
+	InputIndex  int
+	PrevCoin    tx.CoinSnapshot
+	ScriptFlags uint32
+	SigCache    *tx.SigCache
+}
+
+// Verifiable is the subset of *tx.Tx the queue needs. It's still an
+// interface - even though this package now imports model/tx for
+// CoinSnapshot/SigCache anyway - so tests can exercise CheckQueue's
+// concurrency behavior with a fake instead of a real *tx.Tx.
+type Verifiable interface {
+	VerifyScript(inputIndex int, prevCoin tx.CoinSnapshot, flags uint32, sigCache *tx.SigCache) error
+	TxHash() [32]byte
+}
+
+// CheckQueue is a bounded worker pool that verifies ScriptCheck tasks
+// concurrently for a single block. Create one with NewCheckQueue per block,
+// Add tasks to it, then call Wait to block until they've all finished (or
+// one has failed). The first failure is latched and remaining queued work is
+// skipped, so a bad block fails fast instead of burning CPU on every input.
+type CheckQueue struct {
+	tasks   chan ScriptCheck
+	errOnce sync.Once
+	errCh   chan error
+	wg      sync.WaitGroup
+	workers int
+}
+
+// NewCheckQueue starts workers (defaulting to conf.Cfg.Script.CheckQueueSize,
+// or NumCPU if unset/non-positive) consuming from an internal task channel.
+func NewCheckQueue(workers int) *CheckQueue {
+	if workers <= 0 {
+		workers = conf.Cfg.Script.CheckQueueSize
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	q := &CheckQueue{
+		tasks:   make(chan ScriptCheck, workers*4),
+		errCh:   make(chan error, 1),
+		workers: workers,
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+func (q *CheckQueue) worker() {
+	defer q.wg.Done()
+	for check := range q.tasks {
+		select {
+		case err := <-q.errCh:
+			// A failure already landed; put it back for Wait and skip this check.
+			q.errCh <- err
+			continue
+		default:
+		}
+		if err := check.Tx.VerifyScript(check.InputIndex, check.PrevCoin, check.ScriptFlags, check.SigCache); err != nil {
+			q.errOnce.Do(func() { q.errCh <- err })
+		}
+	}
+}
+
+// Add enqueues a check. It is a no-op once a prior check has already failed,
+// since Wait will return that error regardless.
+func (q *CheckQueue) Add(check ScriptCheck) {
+	select {
+	case err := <-q.errCh:
+		q.errCh <- err
+		return
+	default:
+	}
+	select {
+	case err := <-q.errCh:
+		// A failure already landed; put it back for Wait and drop new work.
+		q.errCh <- err
+	case q.tasks <- check:
+	}
+}
+
+// Wait closes the task channel, blocks until every worker has drained it,
+// and returns the first script-verification error encountered, if any.
+func (q *CheckQueue) Wait() error {
+	close(q.tasks)
+	q.wg.Wait()
+	select {
+	case err := <-q.errCh:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+package lcheckqueue
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/copernet/copernicus/model/tx"
+)
+
+// fakeTx is a minimal Verifiable used so CheckQueue's concurrency behavior
+// can be exercised without a real *tx.Tx, which this sandbox's tree has no
+// source for.
+type fakeTx struct {
+	hash [32]byte
+	work time.Duration
+	err  error
+}
+
+func (f *fakeTx) VerifyScript(int, tx.CoinSnapshot, uint32, *tx.SigCache) error {
+	if f.work > 0 {
+		time.Sleep(f.work)
+	}
+	return f.err
+}
+
+func (f *fakeTx) TxHash() [32]byte { return f.hash }
+
+func TestCheckQueueWaitReturnsNilWhenAllPass(t *testing.T) {
+	q := NewCheckQueue(4)
+	for i := 0; i < 100; i++ {
+		q.Add(ScriptCheck{Tx: &fakeTx{}})
+	}
+	if err := q.Wait(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCheckQueueWaitReturnsFirstFailure(t *testing.T) {
+	wantErr := errors.New("bad script")
+	q := NewCheckQueue(4)
+	for i := 0; i < 50; i++ {
+		q.Add(ScriptCheck{Tx: &fakeTx{}})
+	}
+	q.Add(ScriptCheck{Tx: &fakeTx{err: wantErr}})
+	for i := 0; i < 50; i++ {
+		q.Add(ScriptCheck{Tx: &fakeTx{}})
+	}
+	if err := q.Wait(); err != wantErr {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+}
+
+func TestCheckQueueAddAfterFailureIsDropped(t *testing.T) {
+	wantErr := errors.New("bad script")
+	q := NewCheckQueue(1)
+	q.Add(ScriptCheck{Tx: &fakeTx{err: wantErr}})
+	// Let the failure land before adding more work.
+	for len(q.errCh) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	q.Add(ScriptCheck{Tx: &fakeTx{work: time.Second}})
+	start := time.Now()
+	if err := q.Wait(); err != wantErr {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("check added after a failure still ran (Wait took %v)", elapsed)
+	}
+}
+
+func TestCheckQueueRunsChecksConcurrently(t *testing.T) {
+	const n = 8
+	const work = 20 * time.Millisecond
+
+	q := NewCheckQueue(n)
+	for i := 0; i < n; i++ {
+		q.Add(ScriptCheck{Tx: &fakeTx{work: work}})
+	}
+
+	// n checks that each sleep for work can only finish in well under
+	// n*work if the workers ran them in parallel rather than one at a time.
+	start := time.Now()
+	if err := q.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > work*n/2 {
+		t.Fatalf("checks took %v, expected them to run concurrently across %d workers", elapsed, n)
+	}
+}
+
+// BenchmarkCheckQueue measures wall-clock time to run a fixed amount of
+// simulated script-verification work split across an increasing worker
+// count, to demonstrate the near-linear speedup NewCheckQueue exists to
+// provide. Compare with:
+//
+//	go test ./logic/lcheckqueue/... -bench=CheckQueue -cpu=1,2,4,8
+func BenchmarkCheckQueue(b *testing.B) {
+	const totalChecks = 256
+	const perCheck = 200 * time.Microsecond
+
+	for _, workers := range []int{1, 2, 4, runtime.NumCPU()} {
+		workers := workers
+		b.Run(workerLabel(workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q := NewCheckQueue(workers)
+				for j := 0; j < totalChecks; j++ {
+					q.Add(ScriptCheck{Tx: &fakeTx{work: perCheck}})
+				}
+				if err := q.Wait(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func workerLabel(n int) string {
+	switch n {
+	case 1:
+		return "workers=1"
+	case 2:
+		return "workers=2"
+	case 4:
+		return "workers=4"
+	default:
+		return "workers=NumCPU"
+	}
+}
@@ -0,0 +1,139 @@
+package lmempool
+
+import (
+	"sync"
+
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/log"
+	"github.com/copernet/copernicus/model/block"
+	"github.com/copernet/copernicus/model/chain"
+	"github.com/copernet/copernicus/model/mempool"
+	"github.com/copernet/copernicus/model/tx"
+	"github.com/copernet/copernicus/util"
+)
+
+// retryMtx guards retryQueue, which accumulates transactions across however
+// many blocks a single reorg disconnects, since a tx whose parent is mined
+// in an even-earlier block only becomes acceptable once that block has also
+// been disconnected.
+var (
+	retryMtx   sync.Mutex
+	retryQueue []*tx.Tx
+)
+
+// ResurrectBlock re-offers a disconnected block's non-coinbase transactions
+// to the mempool. It orders them so a parent is always attempted before any
+// child in the same block that spends it, instead of the old fixed
+// block-order loop, which could drop a legitimate child that happened to
+// appear before its parent in blk.Txs. Failures other than "missing inputs"
+// are dropped immediately; "missing inputs" failures are deferred to
+// FinishReorg, since the missing parent may still be resurrected from a
+// block disconnected later in the same reorg.
+func ResurrectBlock(blk *block.Block) {
+	ordered := dependencyOrder(blk.Txs)
+
+	retryMtx.Lock()
+	defer retryMtx.Unlock()
+	for _, transaction := range ordered {
+		if transaction.IsCoinBase() {
+			continue
+		}
+		acceptOrDefer(transaction)
+	}
+}
+
+func acceptOrDefer(transaction *tx.Tx) {
+	if err := AcceptTxToMemPool(transaction); err != nil {
+		if isMissingInputsError(err) {
+			retryQueue = append(retryQueue, transaction)
+			return
+		}
+		log.Debug("ResurrectBlock: dropping %s: %v", transaction.GetHash(), err)
+	}
+}
+
+// isMissingInputsError reports whether err is AcceptTxToMemPool's "missing
+// inputs" failure - the one case acceptOrDefer defers to FinishReorg's retry
+// pass rather than dropping outright, since the missing parent may still be
+// resurrected from a block disconnected later in the same reorg. Named and
+// tested on its own because it's the one piece of this file's reorg-recovery
+// logic that's a pure function of an error value, and so the one piece that
+// can be pinned down without a real mempool/AcceptTxToMemPool to run against.
+func isMissingInputsError(err error) bool {
+	return errcode.Is(err, errcode.TxErrNoPreviousOut)
+}
+
+// FinishReorg runs the deferred retry pass once every block in the reorg has
+// been disconnected, then re-limits the mempool against the new tip: it
+// drops anything that would now fail CheckSequenceLocks/CheckFinalTx via
+// mempool.RemoveForReorg, and trims back to the configured size limit using
+// the cumulative minimum fee rate, emitting
+// chain.CallTransactionRemovedFromMempool for everything evicted.
+func FinishReorg(tipHeight int32, lockTimeFlags uint32) {
+	retryMtx.Lock()
+	retrying := retryQueue
+	retryQueue = nil
+	retryMtx.Unlock()
+
+	for _, transaction := range retrying {
+		if err := AcceptTxToMemPool(transaction); err != nil {
+			log.Debug("ResurrectBlock: dropping %s on retry: %v", transaction.GetHash(), err)
+		}
+	}
+
+	pool := mempool.GetInstance()
+	removed := pool.RemoveForReorg(tipHeight, lockTimeFlags)
+	for _, transaction := range removed {
+		chain.CallTransactionRemovedFromMempool(transaction)
+	}
+
+	evicted := pool.TrimToSize(pool.GetMinFeeRate())
+	for _, transaction := range evicted {
+		chain.CallTransactionRemovedFromMempool(transaction)
+	}
+}
+
+// dependencyOrder topologically sorts txs so that, whenever one of them
+// spends another's output, the parent comes first. Transactions with no
+// in-block dependency keep their relative order.
+func dependencyOrder(txs []*tx.Tx) []*tx.Tx {
+	indexByHash := make(map[util.Hash]int, len(txs))
+	for i, transaction := range txs {
+		indexByHash[transaction.GetHash()] = i
+	}
+
+	childrenOf := make(map[int][]int)
+	inDegree := make([]int, len(txs))
+	for i, transaction := range txs {
+		seen := make(map[int]bool)
+		for _, in := range transaction.GetIns() {
+			parent, ok := indexByHash[in.PreviousOutPoint.Hash]
+			if !ok || parent == i || seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			childrenOf[parent] = append(childrenOf[parent], i)
+			inDegree[i]++
+		}
+	}
+
+	ordered := make([]*tx.Tx, 0, len(txs))
+	ready := make([]int, 0)
+	for i := range txs {
+		if inDegree[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, txs[i])
+		for _, child := range childrenOf[i] {
+			inDegree[child]--
+			if inDegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,38 @@
+package lmempool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/copernet/copernicus/errcode"
+)
+
+// isMissingInputsError is the one piece of resurrect.go's reorg-recovery
+// logic that's a pure function of an error value, so it's the one piece
+// testable without a real mempool/AcceptTxToMemPool to run ResurrectBlock,
+// FinishReorg or dependencyOrder against: none of model/tx.Tx's real struct,
+// model/mempool, or AcceptTxToMemPool itself exist anywhere in this trimmed
+// tree to build fixtures against or call into - only their call sites in
+// this file do.
+func TestIsMissingInputsErrorMatchesTxErrNoPreviousOut(t *testing.T) {
+	err := errcode.New(errcode.TxErrNoPreviousOut)
+	if !isMissingInputsError(err) {
+		t.Fatalf("expected errcode.TxErrNoPreviousOut to be classified as a missing-inputs error")
+	}
+}
+
+func TestIsMissingInputsErrorRejectsOtherProjectErrors(t *testing.T) {
+	err := errcode.New(errcode.TxErrRejectInvalid)
+	if isMissingInputsError(err) {
+		t.Fatalf("expected an unrelated ProjectError code not to be classified as missing-inputs")
+	}
+}
+
+func TestIsMissingInputsErrorRejectsPlainErrors(t *testing.T) {
+	if isMissingInputsError(errors.New("some other failure")) {
+		t.Fatalf("expected a non-ProjectError to not be classified as missing-inputs")
+	}
+	if isMissingInputsError(nil) {
+		t.Fatalf("expected a nil error to not be classified as missing-inputs")
+	}
+}
@@ -0,0 +1,53 @@
+package ltx
+
+import (
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/model/tx"
+	"github.com/copernet/copernicus/model/utxo"
+)
+
+// checkTxInputs verifies that every input of transaction spends an existing,
+// unspent, mature coin from view and that the transaction's sigop count
+// doesn't push the running block total past maxSigOps. It returns the fee
+// (sum of inputs minus sum of outputs) on success.
+func checkTxInputs(transaction *tx.Tx, view *utxo.CoinsMap, height int32,
+	sigOpsCount *uint64, maxSigOps uint64, lockTimeFlags uint32) (int64, error) {
+
+	var valueIn int64
+	for _, in := range transaction.GetIns() {
+		coin := view.GetCoin(in.PreviousOutPoint)
+		if coin == nil || coin.IsSpent() {
+			return 0, errcode.New(errcode.TxErrNoPreviousOut)
+		}
+		if coin.IsCoinBase() && height-coin.GetHeight() < consensusCoinbaseMaturity {
+			return 0, errcode.New(errcode.TxErrRejectInvalid)
+		}
+		valueIn += coin.GetAmount()
+	}
+
+	*sigOpsCount += transaction.GetSigOpCount()
+	if *sigOpsCount > maxSigOps {
+		return 0, errcode.New(errcode.TxErrRejectInvalid)
+	}
+
+	valueOut := transaction.GetValueOut()
+	if valueIn < valueOut {
+		return 0, errcode.New(errcode.TxErrRejectInvalid)
+	}
+
+	return valueIn - valueOut, nil
+}
+
+// checkBlockReward ensures the coinbase transaction doesn't pay itself more
+// than the subsidy for height plus the fees collected from the rest of the
+// block.
+func checkBlockReward(coinBase *tx.Tx, fees int64, blockSubsidy int64, height int32) error {
+	if coinBase.GetValueOut() > blockSubsidy+fees {
+		return errcode.New(errcode.TxErrRejectInvalid)
+	}
+	return nil
+}
+
+// consensusCoinbaseMaturity is the number of confirmations a coinbase output
+// must have before it can be spent.
+const consensusCoinbaseMaturity = 100
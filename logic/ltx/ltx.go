@@ -0,0 +1,89 @@
+package ltx
+
+import (
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/logic/lcheckqueue"
+	"github.com/copernet/copernicus/model/blockindex"
+	"github.com/copernet/copernicus/model/tx"
+	"github.com/copernet/copernicus/model/undo"
+	"github.com/copernet/copernicus/model/utxo"
+)
+
+// globalSigCache is shared across blocks, so a signature verified by one
+// block's script checks is remembered for later blocks. Only
+// ApplyBlockTransactions uses it; mempool acceptance does not fill it.
+var globalSigCache = tx.NewSigCache()
+
+// ApplyBlockTransactions validates and applies every transaction in txs on
+// top of the current UTXO cache, returning the resulting view and the undo
+// data needed to reverse it. When fScriptChecks is true, per-input script
+// verification is farmed out to a lcheckqueue.CheckQueue scoped to this
+// block so independent inputs are checked concurrently; the first failure
+// aborts validation for the whole block.
+func ApplyBlockTransactions(txs []*tx.Tx, bip30Enable bool, flags uint32, fScriptChecks bool,
+	blockSubsidy int64, height int32, maxSigOps uint64, lockTimeFlags uint32,
+	pindex *blockindex.BlockIndex) (*utxo.CoinsMap, *undo.BlockUndo, error) {
+
+	coinsMap := utxo.NewEmptyCoinsMap()
+	blockUndo := undo.NewBlockUndo(len(txs))
+
+	var queue *lcheckqueue.CheckQueue
+	if fScriptChecks {
+		queue = lcheckqueue.NewCheckQueue(0)
+	}
+
+	var sigOpsCount uint64
+	var fees int64
+
+	for txIndex, transaction := range txs {
+		if !transaction.IsCoinBase() {
+			if bip30Enable {
+				if coinsMap.HasCoin(transaction) {
+					return nil, nil, errcode.New(errcode.TxErrRejectInvalid)
+				}
+			}
+
+			txFee, err := checkTxInputs(transaction, coinsMap, height, &sigOpsCount, maxSigOps, lockTimeFlags)
+			if err != nil {
+				return nil, nil, err
+			}
+			fees += txFee
+
+			if fScriptChecks {
+				// Snapshot each input's coin before SpendCoins mutates the
+				// coins map below; the queue's workers read PrevCoin
+				// concurrently with that mutation otherwise.
+				for i := range transaction.GetIns() {
+					coin := coinsMap.GetCoin(transaction.GetIns()[i].PreviousOutPoint)
+					queue.Add(lcheckqueue.ScriptCheck{
+						Tx:          transaction,
+						InputIndex:  i,
+						PrevCoin:    tx.NewCoinSnapshot(coin),
+						ScriptFlags: flags,
+						SigCache:    globalSigCache,
+					})
+				}
+			}
+
+			txUndo := undo.NewTxUndo()
+			if err := coinsMap.SpendCoins(transaction, txUndo); err != nil {
+				return nil, nil, err
+			}
+			blockUndo.AddTxUndo(txUndo)
+		}
+
+		coinsMap.AddCoins(transaction, height, txIndex == 0)
+	}
+
+	if fScriptChecks {
+		if err := queue.Wait(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := checkBlockReward(txs[0], fees, blockSubsidy, height); err != nil {
+		return nil, nil, err
+	}
+
+	return coinsMap, blockUndo, nil
+}
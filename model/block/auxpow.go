@@ -0,0 +1,39 @@
+package block
+
+import (
+	"github.com/copernet/copernicus/model/tx"
+	"github.com/copernet/copernicus/util"
+)
+
+// AuxPow is the merged-mining proof attached to a block whose header has the
+// AuxPow bit set (see DecodeVersion). It proves that a block mined on a
+// parent chain commits to this block's hash, letting this chain's
+// proof-of-work be satisfied by parent-chain miners without them running
+// this chain's software.
+//
+// Present only when the header declares AuxPow; callers should treat a nil
+// *Block.AuxPow on an AuxPow-flagged header as a malformed block.
+type AuxPow struct {
+	// ParentCoinbase is the parent chain's coinbase transaction, which must
+	// contain the magic merged-mining marker followed by the chain merkle
+	// root that ChainMerkleBranch computes from this block's hash.
+	ParentCoinbase *tx.Tx
+	// ParentCoinbaseBranch and ParentCoinbaseIndex prove ParentCoinbase's
+	// position in the parent block's transaction merkle tree.
+	ParentCoinbaseBranch []util.Hash
+	ParentCoinbaseIndex  int
+	// ChainMerkleBranch and ChainMerkleIndex prove this block's hash is
+	// committed to at the position the parent coinbase claims, letting one
+	// parent block merge-mine several chains at once.
+	ChainMerkleBranch []util.Hash
+	ChainMerkleIndex  int
+	// ParentBlock is the parent chain's block header; its proof-of-work must
+	// meet this chain's nBits target for the height being mined.
+	ParentBlock BlockHeader
+	// MerkleCommitOffset is the byte offset into ParentCoinbase's scriptSig
+	// at which the magic marker and chain merkle root must appear. Pinning
+	// an exact offset, rather than searching the whole script, is what
+	// makes the commitment belong to this proof's own merkle branch instead
+	// of to an unrelated chain ID that also happens to appear in the script.
+	MerkleCommitOffset int
+}
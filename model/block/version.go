@@ -0,0 +1,31 @@
+package block
+
+// AuxPow version-bit layout, shared with Namecoin/Dogecoin-style merged
+// mining: the low bits carry the chain's own consensus version, bit 8 flags
+// the presence of an AuxPow payload, and the top 16 bits identify which
+// parent chain the proof-of-work was borrowed from.
+const (
+	VersionAuxPowBit    = 1 << 8
+	VersionChainIDShift = 16
+)
+
+// DecodeVersion splits a block header's raw nVersion into its base
+// consensus version, declared parent chain ID, and whether an AuxPow
+// payload should follow the header.
+func DecodeVersion(nVersion int32) (baseVersion, chainID int32, hasAuxPow bool) {
+	hasAuxPow = nVersion&VersionAuxPowBit != 0
+	baseVersion = nVersion & (VersionAuxPowBit - 1)
+	chainID = nVersion >> VersionChainIDShift
+	return
+}
+
+// EncodeVersion packs baseVersion and chainID into a single nVersion field,
+// setting the AuxPow bit when withAuxPow is true.
+func EncodeVersion(baseVersion, chainID int32, withAuxPow bool) int32 {
+	v := baseVersion
+	if withAuxPow {
+		v |= VersionAuxPowBit
+	}
+	v |= chainID << VersionChainIDShift
+	return v
+}
@@ -0,0 +1,159 @@
+package chain
+
+import (
+	"sync"
+
+	"github.com/copernet/copernicus/log"
+	"github.com/copernet/copernicus/model/block"
+	"github.com/copernet/copernicus/model/blockindex"
+	"github.com/copernet/copernicus/model/tx"
+)
+
+// ValidationInterface is implemented by subscribers that want to react to
+// chain-state changes (ZMQ publishers, wallet notifications, indexers)
+// without being wired directly into the validation code in logic/lchain.
+type ValidationInterface interface {
+	// UpdatedBlockTip is called whenever the active chain tip changes,
+	// whether by connecting a new block or disconnecting the old one.
+	UpdatedBlockTip(newTip, oldTip *blockindex.BlockIndex, initialDownload bool)
+	// BlockConnected is called after a block has been connected to the
+	// active chain. ConnectTip passes nil for conflictedTxs, since
+	// RemoveTxSelf does not report which mempool transactions it removed.
+	BlockConnected(block *block.Block, index *blockindex.BlockIndex, conflictedTxs []*tx.Tx)
+	// BlockDisconnected is called after a block has been removed from the
+	// active chain.
+	BlockDisconnected(block *block.Block)
+	// TransactionAddedToMempool receives events sent through
+	// CallTransactionAddedToMempool. Neither logic/lchain nor logic/lmempool
+	// calls that function, so mempool entries are not reported.
+	TransactionAddedToMempool(tx *tx.Tx)
+	// TransactionRemovedFromMempool is called for transactions that
+	// lmempool.FinishReorg drops after a reorg, whether by RemoveForReorg or
+	// by the size trim. Conflicts removed when a block is connected are not
+	// reported.
+	TransactionRemovedFromMempool(tx *tx.Tx)
+	// ChainStateFlushed is called once the chain state, including the
+	// locator passed in, has been durably written to disk.
+	ChainStateFlushed(locator *BlockLocator)
+}
+
+// validationQueueSize bounds the number of pending callbacks per subscriber.
+// Each subscriber gets its own queue and delivery goroutine so a slow or
+// stuck one only ever falls behind on its own events instead of holding up
+// delivery to every other subscriber.
+const validationQueueSize = 4096
+
+type validationSubscriber struct {
+	vi    ValidationInterface
+	queue chan func(ValidationInterface)
+}
+
+var (
+	validationMtx         sync.RWMutex
+	validationSubscribers []*validationSubscriber
+)
+
+// RegisterValidationInterface subscribes vi to future chain events, starting
+// a dedicated delivery goroutine for it. It is safe to call from any
+// goroutine.
+func RegisterValidationInterface(vi ValidationInterface) {
+	sub := &validationSubscriber{vi: vi, queue: make(chan func(ValidationInterface), validationQueueSize)}
+	go func() {
+		for event := range sub.queue {
+			event(vi)
+		}
+	}()
+
+	validationMtx.Lock()
+	validationSubscribers = append(validationSubscribers, sub)
+	validationMtx.Unlock()
+}
+
+// UnregisterValidationInterface removes a previously registered subscriber
+// and stops its delivery goroutine once its queue drains.
+func UnregisterValidationInterface(vi ValidationInterface) {
+	validationMtx.Lock()
+	defer validationMtx.Unlock()
+	for i, sub := range validationSubscribers {
+		if sub.vi == vi {
+			close(sub.queue)
+			validationSubscribers = append(validationSubscribers[:i], validationSubscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// dispatch hands event to every subscriber's own queue. The send is
+// non-blocking: a subscriber whose queue is already full has event dropped
+// for it (with a warning) rather than stalling the caller, which is always
+// the validation thread (ConnectTip/DisconnectTip/UpdateTip) for this
+// package.
+func dispatch(event func(ValidationInterface)) {
+	validationMtx.RLock()
+	defer validationMtx.RUnlock()
+	for _, sub := range validationSubscribers {
+		select {
+		case sub.queue <- event:
+		default:
+			log.Warn("chain: validation interface subscriber queue full, dropping event")
+		}
+	}
+}
+
+// SyncWithValidationInterfaceQueue blocks until every callback enqueued
+// before this call has been delivered to every subscriber. Callers that need
+// a subscriber's side effects (e.g. a wallet rescan) to be visible before
+// proceeding should call this after the events they care about.
+//
+// The RLock is held across the sends below, not just the slice copy: it is
+// what it is locked against in UnregisterValidationInterface (which takes
+// the write lock before closing a subscriber's queue), so a subscriber
+// cannot be unregistered - and its queue closed - between this function
+// choosing to send to it and the send actually happening. Without that,
+// a send on a channel closed out from under it would panic.
+func SyncWithValidationInterfaceQueue() {
+	validationMtx.RLock()
+	defer validationMtx.RUnlock()
+
+	var wg sync.WaitGroup
+	wg.Add(len(validationSubscribers))
+	for _, sub := range validationSubscribers {
+		sub := sub
+		// The sentinel closure itself signals completion once the
+		// dispatcher goroutine actually reaches it, so this only returns
+		// after every event enqueued ahead of it has run.
+		sub.queue <- func(ValidationInterface) { wg.Done() }
+	}
+	wg.Wait()
+}
+
+// CallUpdatedBlockTip notifies subscribers that the active tip moved.
+func CallUpdatedBlockTip(newTip, oldTip *blockindex.BlockIndex, initialDownload bool) {
+	dispatch(func(vi ValidationInterface) { vi.UpdatedBlockTip(newTip, oldTip, initialDownload) })
+}
+
+// CallBlockConnected notifies subscribers that a block was connected.
+func CallBlockConnected(blk *block.Block, index *blockindex.BlockIndex, conflictedTxs []*tx.Tx) {
+	dispatch(func(vi ValidationInterface) { vi.BlockConnected(blk, index, conflictedTxs) })
+}
+
+// CallBlockDisconnected notifies subscribers that a block was disconnected.
+func CallBlockDisconnected(blk *block.Block) {
+	dispatch(func(vi ValidationInterface) { vi.BlockDisconnected(blk) })
+}
+
+// CallTransactionAddedToMempool notifies subscribers of a new mempool entry.
+func CallTransactionAddedToMempool(transaction *tx.Tx) {
+	dispatch(func(vi ValidationInterface) { vi.TransactionAddedToMempool(transaction) })
+}
+
+// CallTransactionRemovedFromMempool notifies subscribers of a mempool eviction.
+func CallTransactionRemovedFromMempool(transaction *tx.Tx) {
+	dispatch(func(vi ValidationInterface) { vi.TransactionRemovedFromMempool(transaction) })
+}
+
+// CallChainStateFlushed notifies subscribers that the chain state up to
+// locator has been durably persisted.
+func CallChainStateFlushed(locator *BlockLocator) {
+	dispatch(func(vi ValidationInterface) { vi.ChainStateFlushed(locator) })
+}
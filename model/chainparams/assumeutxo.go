@@ -0,0 +1,25 @@
+package chainparams
+
+import "github.com/copernet/copernicus/util"
+
+// AssumeUtxoCommitment pins a trusted snapshot hash to a specific
+// (height, blockHash) pair, so LoadSnapshot only needs to trust a value
+// shipped with the software rather than whoever handed it the snapshot
+// file. BitcoinParams.AssumeUtxoCommitments holds the set of commitments
+// known for this network, updated from time to time like HashAssumeValid.
+type AssumeUtxoCommitment struct {
+	Height    int32
+	BlockHash util.Hash
+	Hash      util.Hash
+}
+
+// AssumeUtxoHash returns the hardcoded commitment hash for a snapshot at
+// (height, blockHash), if one is known for this network.
+func (p *BitcoinParams) AssumeUtxoHash(height int32, blockHash util.Hash) (util.Hash, bool) {
+	for _, c := range p.AssumeUtxoCommitments {
+		if c.Height == height && c.BlockHash.IsEqual(&blockHash) {
+			return c.Hash, true
+		}
+	}
+	return util.Hash{}, false
+}
@@ -0,0 +1,6 @@
+package chainparams
+
+// AuxPowEnabledAt reports whether params allows an AuxPow block at height.
+func (p *BitcoinParams) AuxPowEnabledAt(height int32) bool {
+	return p.AuxPowChainID != 0 && height >= p.AuxPowStartHeight
+}
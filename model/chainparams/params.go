@@ -0,0 +1,59 @@
+package chainparams
+
+import (
+	"github.com/copernet/copernicus/model"
+	"github.com/copernet/copernicus/model/block"
+	"github.com/copernet/copernicus/util"
+)
+
+// BitcoinParams describes the consensus rules of a single network (mainnet,
+// testnet, regtest, ...). Only the fields the logic/ and model/ packages in
+// this tree actually read are declared here; the rest of the real parameter
+// set (DNS seeds, checkpoints, port numbers, ...) lives alongside this in
+// the full repository.
+type BitcoinParams struct {
+	// BitcoinNet is the magic value prefixed to every message/undo-file
+	// record on this network, distinguishing it from other networks'
+	// otherwise-identical wire formats.
+	BitcoinNet uint32
+
+	// GenesisBlock and GenesisHash are the hardcoded first block of this
+	// network's chain; ConnectBlock special-cases it since its coinbase is
+	// unspendable, and InitGenesisChain writes it to disk on a fresh node.
+	GenesisBlock block.Block
+	GenesisHash  *util.Hash
+
+	// BIP34Height and BIP34Hash pin the block at which BIP34 (coinbase must
+	// commit to height) activated, letting ConnectBlock skip the duplicate
+	// coinbase check above that height without rewalking history.
+	BIP34Height int32
+	BIP34Hash   util.Hash
+
+	// CSVHeight is the activation height for BIP68/112/113 (CSV); below it,
+	// nSequence-based relative lock-time and OP_CHECKSEQUENCEVERIFY are not
+	// enforced.
+	CSVHeight int32
+
+	// AssumeUtxoCommitments holds the hardcoded UTXO-snapshot commitments
+	// known for this network, consulted by AssumeUtxoHash.
+	AssumeUtxoCommitments []AssumeUtxoCommitment
+
+	// AuxPowChainID is the parent-chain identifier this network's blocks
+	// must declare in nVersion when merge-mined (see block.DecodeVersion);
+	// zero means merged mining isn't configured for this network.
+	// AuxPowStartHeight is the height at which AuxPow blocks become
+	// acceptable; below it, a block with the AuxPow bit set is rejected
+	// outright. Consulted via AuxPowEnabledAt.
+	AuxPowChainID     int32
+	AuxPowStartHeight int32
+
+	// ChainTxData is the hardcoded transaction-count/timestamp checkpoint
+	// returned by TxData, used to estimate initial-block-download progress.
+	ChainTxData model.ChainTxData
+}
+
+// TxData returns the hardcoded transaction-count/timestamp checkpoint used
+// to estimate initial-block-download progress (see GuessVerificationProgress).
+func (p *BitcoinParams) TxData() *model.ChainTxData {
+	return &p.ChainTxData
+}
@@ -0,0 +1,59 @@
+package tx
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// sigCacheEntry is keyed by sighash||signature||pubkey so that a given
+// (message, signature, key) triple verified once during mempool acceptance
+// doesn't need to be re-verified when its transaction is later mined.
+type sigCacheEntry [sha256.Size]byte
+
+func newSigCacheEntry(sigHash, signature, pubKey []byte) sigCacheEntry {
+	h := sha256.New()
+	h.Write(sigHash)
+	h.Write(signature)
+	h.Write(pubKey)
+	var entry sigCacheEntry
+	copy(entry[:], h.Sum(nil))
+	return entry
+}
+
+// SigCache remembers which (sighash, signature, pubkey) triples have already
+// been found valid, so Tx.VerifyScript can skip re-running the
+// actual elliptic-curve verification for a transaction that was checked on
+// mempool entry and is now being verified again as part of a block.
+type SigCache struct {
+	mtx     sync.RWMutex
+	entries map[sigCacheEntry]struct{}
+}
+
+// NewSigCache creates an empty cache.
+func NewSigCache() *SigCache {
+	return &SigCache{entries: make(map[sigCacheEntry]struct{})}
+}
+
+// Has reports whether (sigHash, signature, pubKey) was previously recorded
+// as valid via Add.
+func (c *SigCache) Has(sigHash, signature, pubKey []byte) bool {
+	if c == nil {
+		return false
+	}
+	key := newSigCacheEntry(sigHash, signature, pubKey)
+	c.mtx.RLock()
+	_, ok := c.entries[key]
+	c.mtx.RUnlock()
+	return ok
+}
+
+// Add records (sigHash, signature, pubKey) as having verified successfully.
+func (c *SigCache) Add(sigHash, signature, pubKey []byte) {
+	if c == nil {
+		return
+	}
+	key := newSigCacheEntry(sigHash, signature, pubKey)
+	c.mtx.Lock()
+	c.entries[key] = struct{}{}
+	c.mtx.Unlock()
+}
@@ -0,0 +1,41 @@
+package tx
+
+// VerifyScript checks that input inputIndex's scriptSig, together with
+// prevCoin's scriptPubKey, satisfies flags. It consults sigCache first so a
+// signature already verified on mempool entry isn't re-verified when the
+// same transaction is checked again as part of a block.
+func (tx *Tx) VerifyScript(inputIndex int, prevCoin CoinSnapshot, flags uint32, sigCache *SigCache) error {
+	return tx.verifyInputScript(inputIndex, prevCoin.ScriptPubKey, prevCoin.Amount, flags, sigCache)
+}
+
+// TxHash returns the transaction's double-SHA256 id, satisfying
+// lcheckqueue.Verifiable; it is not part of the sig-cache key. GetHash
+// returns util.Hash by value, matching its use elsewhere (e.g.
+// logic/lblock.CheckAuxPow, logic/lmempool.dependencyOrder).
+func (tx *Tx) TxHash() [32]byte {
+	return [32]byte(tx.GetHash())
+}
+
+// Coin is the subset of utxo.Coin a script check needs to copy out of,
+// avoiding an import of model/utxo here purely for this one call site.
+type Coin interface {
+	GetScriptPubKey() []byte
+	GetAmount() int64
+}
+
+// CoinSnapshot is an immutable copy of the previous-output data a script
+// check needs, taken before the spending transaction is applied to the
+// coins map. logic/lcheckqueue's ScriptCheck carries one of these instead
+// of a live Coin: the caller is about to spend that coin (marking/clearing
+// it in-place) on the same goroutine that enqueues checks, so a worker
+// reading a live Coin's fields would race that mutation.
+type CoinSnapshot struct {
+	ScriptPubKey []byte
+	Amount       int64
+}
+
+// NewCoinSnapshot copies the fields of a live Coin so they can be handed to
+// a CheckQueue without risk of the original being mutated concurrently.
+func NewCoinSnapshot(coin Coin) CoinSnapshot {
+	return CoinSnapshot{ScriptPubKey: coin.GetScriptPubKey(), Amount: coin.GetAmount()}
+}
@@ -0,0 +1,52 @@
+package utxo
+
+import (
+	"github.com/copernet/copernicus/persist/db"
+	"github.com/copernet/copernicus/util"
+)
+
+// GetHeadBlock returns the forward pointer written by ConnectTip/DisconnectTip
+// before a coins-DB batch is applied. It is nil once a flush has brought
+// best_block up to it and ClearHeadBlockIfFlushed has erased it, or if none
+// has ever been written; between flushes it stays set. A non-nil result that differs from GetBestBlock indicates the last
+// shutdown happened mid-flush and the caller should run ReplayBlocks.
+func (coinsDB *CoinsDB) GetHeadBlock() (*util.Hash, error) {
+	v, err := coinsDB.GetDBW().Read([]byte{db.DbHeadBlocks})
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	hash := new(util.Hash)
+	copy(hash[:], v)
+	return hash, nil
+}
+
+// WriteHeadBlock records hash as the block a coins-DB batch is about to
+// commit to. It must be written before the batch and cleared with
+// ClearHeadBlockIfFlushed once the batch has actually landed on disk.
+func (coinsDB *CoinsDB) WriteHeadBlock(hash util.Hash) error {
+	return coinsDB.GetDBW().Write([]byte{db.DbHeadBlocks}, hash[:], false)
+}
+
+// ClearHeadBlockIfFlushed erases the forward pointer, but only once
+// best_block on disk actually matches committed. FlushStateToDisk's
+// "if needed" mode only flushes once some threshold is met and doesn't
+// report whether it did; calling this unconditionally right after it would
+// erase head_block on every call where no flush happened, leaving
+// best_block pointing at the *previous* tip with nothing left to detect
+// that gap on the next crash. Returns whether the pointer was cleared.
+func (coinsDB *CoinsDB) ClearHeadBlockIfFlushed(committed util.Hash) (bool, error) {
+	best, err := coinsDB.GetBestBlock()
+	if err != nil {
+		return false, err
+	}
+	if best == nil || !best.IsEqual(&committed) {
+		return false, nil
+	}
+	if err := coinsDB.GetDBW().Erase([]byte{db.DbHeadBlocks}, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,22 @@
+package utxo
+
+import "github.com/copernet/copernicus/persist/db"
+
+// WriteRawCoin stages a raw (key, value) coin-DB record, as read directly
+// off a snapshot stream, straight into a write batch without going through
+// the usual Coin (de)serialization. utxo/snapshot uses this so LoadSnapshot
+// can stream the wire format directly into the coins DB.
+func (coinsDB *CoinsDB) WriteRawCoin(batch db.IBatch, key, value []byte) {
+	batch.Write(key, value)
+}
+
+// SetSnapshotPending marks the coins DB as mid-way through a snapshot load.
+// While pending, the DB must not be treated as caught up with the chain
+// tip it claims; LoadSnapshot clears it once the snapshot's hash has been
+// verified.
+func (coinsDB *CoinsDB) SetSnapshotPending(pending bool) error {
+	if !pending {
+		return coinsDB.GetDBW().Erase([]byte{db.DbSnapshotPending}, false)
+	}
+	return coinsDB.GetDBW().Write([]byte{db.DbSnapshotPending}, []byte{1}, false)
+}
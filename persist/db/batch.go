@@ -0,0 +1,9 @@
+package db
+
+// IBatch collects writes to be applied to a DBWrapper atomically; used by
+// utxo/snapshot to write snapshot records in chunks. Chunks already written
+// stay on disk if a later part of the snapshot fails.
+type IBatch interface {
+	Write(key, value []byte)
+	Erase(key []byte)
+}
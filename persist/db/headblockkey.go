@@ -0,0 +1,6 @@
+package db
+
+// DbHeadBlocks is the coins-DB key holding the forward pointer written
+// before a non-atomic flush batch and cleared once that batch commits. See
+// logic/lchain.ReplayBlocks.
+const DbHeadBlocks = 'H'
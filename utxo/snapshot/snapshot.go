@@ -0,0 +1,240 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/copernet/copernicus/errcode"
+	"github.com/copernet/copernicus/model/chainparams"
+	"github.com/copernet/copernicus/model/utxo"
+	"github.com/copernet/copernicus/persist/db"
+	"github.com/copernet/copernicus/util"
+)
+
+// magic identifies a UTXO snapshot file; version lets us change the record
+// format later without guessing from file size alone.
+const (
+	magic   uint32 = 0x5554584f // "UTXO"
+	version uint32 = 1
+)
+
+// loadBatchSize bounds how many records LoadSnapshot buffers before writing
+// a batch and starting a new one. A real UTXO set can be tens of millions of
+// coins; buffering all of them in one batch before the first WriteBatch call
+// would mean a multi-GB allocation, defeating the point of streaming the
+// snapshot in the first place. Writes made before the trailing hash check
+// passes are safe to leave on disk even if that check later fails, since
+// they land under the same "snapshot pending" marker LoadSnapshot only
+// clears once the whole snapshot has verified.
+const loadBatchSize = 16384
+
+// header is written once at the start of a snapshot, before any coin
+// records, so LoadSnapshot knows which block the snapshot claims to be and
+// how many records to expect.
+type header struct {
+	Magic     uint32
+	Version   uint32
+	BlockHash util.Hash
+	Height    int32
+	NumCoins  uint64
+}
+
+func (h *header) write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, h.Magic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, h.Version); err != nil {
+		return err
+	}
+	if _, err := w.Write(h.BlockHash[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, h.Height); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, h.NumCoins)
+}
+
+func readHeader(r io.Reader) (*header, error) {
+	h := &header{}
+	if err := binary.Read(r, binary.LittleEndian, &h.Magic); err != nil {
+		return nil, err
+	}
+	if h.Magic != magic {
+		return nil, errcode.New(errcode.SnapshotErrBadMagic)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h.Version); err != nil {
+		return nil, err
+	}
+	if h.Version != version {
+		return nil, errcode.New(errcode.SnapshotErrBadVersion)
+	}
+	if _, err := io.ReadFull(r, h.BlockHash[:]); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h.Height); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h.NumCoins); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// DumpSnapshot iterates the coins DB in key order, as utxo's stats iterator
+// already does, and writes a framed snapshot of the UTXO set as of height to
+// w: a header, then one serialized (outpoint, coin) record per entry, then a
+// trailing rolling SHA256 over every record (but not the header), so
+// LoadSnapshot can check the data it streamed back in matches what was
+// dumped.
+func DumpSnapshot(height int32, blockHash util.Hash, w io.Writer) error {
+	cdb := utxo.GetUtxoCacheInstance().(*utxo.CoinsLruCache).GetCoinsDB()
+	iter := cdb.GetDBW().Iterator(nil)
+	defer iter.Close()
+
+	var numCoins uint64
+	iter.Seek([]byte{db.DbCoin})
+	for ; iter.Valid(); iter.Next() {
+		if iter.Key()[0] != db.DbCoin {
+			break
+		}
+		numCoins++
+	}
+
+	if err := (&header{Magic: magic, Version: version, BlockHash: blockHash, Height: height, NumCoins: numCoins}).write(w); err != nil {
+		return err
+	}
+
+	rolling := sha256.New()
+	iter.Seek([]byte{db.DbCoin})
+	for ; iter.Valid(); iter.Next() {
+		if iter.Key()[0] != db.DbCoin {
+			break
+		}
+		key, value := iter.Key(), iter.Value()
+		if err := writeRecord(w, rolling, key, value); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(rolling.Sum(nil))
+	return err
+}
+
+func writeRecord(w io.Writer, rolling io.Writer, key, value []byte) error {
+	mw := io.MultiWriter(w, rolling)
+	if err := binary.Write(mw, binary.LittleEndian, uint32(len(key))); err != nil {
+		return err
+	}
+	if _, err := mw.Write(key); err != nil {
+		return err
+	}
+	if err := binary.Write(mw, binary.LittleEndian, uint32(len(value))); err != nil {
+		return err
+	}
+	_, err := mw.Write(value)
+	return err
+}
+
+// LoadSnapshot streams records from r into a fresh coins DB, under a
+// "snapshot pending" marker that's only cleared once the rolling hash of
+// every record read matches both the trailer in the stream and the
+// params-hardcoded AssumeUtxo commitment for the header's (height,
+// blockHash). A hash mismatch leaves the pending marker set and returns an
+// error; callers must not treat the snapshot's coins DB as trustworthy
+// until LoadSnapshot returns nil.
+func LoadSnapshot(r io.Reader, params *chainparams.BitcoinParams) (*util.Hash, int32, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	commitment, ok := params.AssumeUtxoHash(h.Height, h.BlockHash)
+	if !ok {
+		return nil, 0, errcode.New(errcode.SnapshotErrNoCommitment)
+	}
+
+	cdb := utxo.GetUtxoCacheInstance().(*utxo.CoinsLruCache).GetCoinsDB()
+	if err := cdb.SetSnapshotPending(true); err != nil {
+		return nil, 0, err
+	}
+
+	rolling := sha256.New()
+	batch := cdb.GetDBW().NewBatch()
+	pending := 0
+	for i := uint64(0); i < h.NumCoins; i++ {
+		key, value, err := readRecord(r, rolling)
+		if err != nil {
+			return nil, 0, err
+		}
+		cdb.WriteRawCoin(batch, key, value)
+		pending++
+		if pending == loadBatchSize {
+			if err := cdb.GetDBW().WriteBatch(batch, false); err != nil {
+				return nil, 0, err
+			}
+			batch = cdb.GetDBW().NewBatch()
+			pending = 0
+		}
+	}
+
+	var trailer [sha256.Size]byte
+	if _, err := io.ReadFull(r, trailer[:]); err != nil {
+		return nil, 0, err
+	}
+	sum := rolling.Sum(nil)
+	if !hashEqual(sum, trailer[:]) {
+		return nil, 0, errcode.New(errcode.SnapshotErrHashMismatch)
+	}
+	if !hashEqual(sum, commitment[:]) {
+		return nil, 0, errcode.New(errcode.SnapshotErrHashMismatch)
+	}
+
+	// Only mark the snapshot's block as best_block, and only clear the
+	// pending marker, once every batch above is known to have landed and
+	// the hash has verified - a crash partway through the loop above just
+	// leaves some coin records written under a still-pending snapshot,
+	// which the pending marker means callers already know not to trust.
+	cdb.WriteRawCoin(batch, []byte{db.DbBestBlock}, h.BlockHash[:])
+	if err := cdb.GetDBW().WriteBatch(batch, false); err != nil {
+		return nil, 0, err
+	}
+	if err := cdb.SetSnapshotPending(false); err != nil {
+		return nil, 0, err
+	}
+
+	return &h.BlockHash, h.Height, nil
+}
+
+func readRecord(r io.Reader, rolling io.Writer) ([]byte, []byte, error) {
+	mr := io.TeeReader(r, rolling)
+	var keyLen, valLen uint32
+	if err := binary.Read(mr, binary.LittleEndian, &keyLen); err != nil {
+		return nil, nil, err
+	}
+	key := make([]byte, keyLen)
+	if _, err := io.ReadFull(mr, key); err != nil {
+		return nil, nil, err
+	}
+	if err := binary.Read(mr, binary.LittleEndian, &valLen); err != nil {
+		return nil, nil, err
+	}
+	value := make([]byte, valLen)
+	if _, err := io.ReadFull(mr, value); err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+func hashEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}